@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goversion
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BranchStrategy derives the final GitVersion for a branch. It is applied
+// after every other Option, once Info.GitBranch, GitVersion and GitCommit
+// are known.
+type BranchStrategy interface {
+	Apply(info *Info) string
+}
+
+// GitFlowStrategy implements the classic Git-Flow branch-to-version
+// mapping: main/master keep the release version, release/* and hotfix/*
+// branches become release candidates, feature/* branches become
+// milestones, and anything else (including a detached HEAD) is treated
+// as a snapshot.
+type GitFlowStrategy struct{}
+
+// Apply implements BranchStrategy.
+func (GitFlowStrategy) Apply(info *Info) string {
+	shortCommit := shortSHA(info.GitCommit)
+
+	switch {
+	case info.GitBranch == "main" || info.GitBranch == "master":
+		return info.GitVersion
+	case strings.HasPrefix(info.GitBranch, "release/"), strings.HasPrefix(info.GitBranch, "hotfix/"):
+		return info.GitVersion + "-RC+" + shortCommit
+	case strings.HasPrefix(info.GitBranch, "feature/"):
+		return info.GitVersion + "-M+" + shortCommit
+	default:
+		return info.GitVersion + "-SNAPSHOT+" + shortCommit
+	}
+}
+
+// TrunkBasedStrategy implements trunk-based development: main (or master)
+// keeps the release version, and every other branch (including a detached HEAD) is
+// a development build named after the branch's short commit.
+type TrunkBasedStrategy struct{}
+
+// Apply implements BranchStrategy.
+func (TrunkBasedStrategy) Apply(info *Info) string {
+	if info.GitBranch == "main" || info.GitBranch == "master" {
+		return info.GitVersion
+	}
+	return info.GitVersion + "-dev." + shortSHA(info.GitCommit)
+}
+
+// GitHubFlowStrategy implements GitHub Flow: main (or master) keeps the
+// release version, release/x.y branches become numbered release candidates
+// (<x.y.z>-rc.<N>, N being the number of commits since the nearest
+// reachable tag, as resolved by WithGitDescribe), and everything else is a
+// snapshot.
+type GitHubFlowStrategy struct{}
+
+// Apply implements BranchStrategy.
+func (GitHubFlowStrategy) Apply(info *Info) string {
+	switch {
+	case info.GitBranch == "main" || info.GitBranch == "master":
+		return info.GitVersion
+	case strings.HasPrefix(info.GitBranch, "release/"):
+		xy := strings.TrimPrefix(info.GitBranch, "release/")
+		patch := "0"
+		if parts := strings.SplitN(info.GitVersion, ".", 3); len(parts) == 3 {
+			patch = parts[2]
+			// Drop any describe/pre-release/build suffix, e.g. "3-5-gabc1234".
+			if idx := strings.IndexAny(patch, "-+"); idx >= 0 {
+				patch = patch[:idx]
+			}
+		}
+		return fmt.Sprintf("%s.%s-rc.%d", xy, patch, info.gitCommitDistance)
+	default:
+		return info.GitVersion + "-SNAPSHOT+" + shortSHA(info.GitCommit)
+	}
+}
+
+// FuncStrategy adapts a plain function into a BranchStrategy, for
+// user-defined branching rules.
+type FuncStrategy func(info *Info) string
+
+// Apply implements BranchStrategy.
+func (f FuncStrategy) Apply(info *Info) string {
+	return f(info)
+}
+
+// shortSHA truncates a commit sha to its conventional 7-character form.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goversion
+
+import "testing"
+
+func TestGitFlowStrategyDetachedHead(t *testing.T) {
+	info := &Info{GitVersion: "1.0.0", GitCommit: "02af8e0619ca3f625bfbc25e60289e0eba222c35", GitBranch: ""}
+	want := "1.0.0-SNAPSHOT+02af8e0"
+	if got := (GitFlowStrategy{}).Apply(info); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestGitFlowStrategyHotfix(t *testing.T) {
+	info := &Info{GitVersion: "1.0.0", GitCommit: "02af8e0619ca3f625bfbc25e60289e0eba222c35", GitBranch: "hotfix/1.0.1"}
+	want := "1.0.0-RC+02af8e0"
+	if got := (GitFlowStrategy{}).Apply(info); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestTrunkBasedStrategy(t *testing.T) {
+	commit := "02af8e0619ca3f625bfbc25e60289e0eba222c35"
+	t.Run("main", func(t *testing.T) {
+		info := &Info{GitVersion: "1.0.0", GitCommit: commit, GitBranch: "main"}
+		if got := (TrunkBasedStrategy{}).Apply(info); got != "1.0.0" {
+			t.Fatalf("expected %q, got %q", "1.0.0", got)
+		}
+	})
+	t.Run("feature branch", func(t *testing.T) {
+		info := &Info{GitVersion: "1.0.0", GitCommit: commit, GitBranch: "add-widget"}
+		want := "1.0.0-dev.02af8e0"
+		if got := (TrunkBasedStrategy{}).Apply(info); got != want {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+	})
+	t.Run("detached head", func(t *testing.T) {
+		info := &Info{GitVersion: "1.0.0", GitCommit: commit, GitBranch: ""}
+		want := "1.0.0-dev.02af8e0"
+		if got := (TrunkBasedStrategy{}).Apply(info); got != want {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+	})
+}
+
+func TestGitHubFlowStrategy(t *testing.T) {
+	commit := "02af8e0619ca3f625bfbc25e60289e0eba222c35"
+	t.Run("main", func(t *testing.T) {
+		info := &Info{GitVersion: "1.2.0", GitCommit: commit, GitBranch: "main"}
+		if got := (GitHubFlowStrategy{}).Apply(info); got != "1.2.0" {
+			t.Fatalf("expected %q, got %q", "1.2.0", got)
+		}
+	})
+	t.Run("release branch", func(t *testing.T) {
+		info := &Info{GitVersion: "1.2.3", GitCommit: commit, GitBranch: "release/1.2", gitCommitDistance: 5}
+		want := "1.2.3-rc.5"
+		if got := (GitHubFlowStrategy{}).Apply(info); got != want {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+	})
+	t.Run("other branch", func(t *testing.T) {
+		info := &Info{GitVersion: "1.2.0", GitCommit: commit, GitBranch: "develop"}
+		want := "1.2.0-SNAPSHOT+02af8e0"
+		if got := (GitHubFlowStrategy{}).Apply(info); got != want {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+	})
+}
+
+func TestFuncStrategy(t *testing.T) {
+	strategy := FuncStrategy(func(info *Info) string {
+		return info.GitVersion + "+custom"
+	})
+	info := &Info{GitVersion: "1.0.0"}
+	if got := strategy.Apply(info); got != "1.0.0+custom" {
+		t.Fatalf("expected %q, got %q", "1.0.0+custom", got)
+	}
+}
+
+func TestWithBranchStrategy(t *testing.T) {
+	sut := GetVersionInfo(
+		WithBranchStrategy(TrunkBasedStrategy{}),
+		func(i *Info) {
+			i.GitVersion = "1.0.0"
+			i.GitCommit = "02af8e0619ca3f625bfbc25e60289e0eba222c35"
+			i.GitBranch = "main"
+		},
+	)
+	if sut.GitVersion != "1.0.0" {
+		t.Fatalf("expected %q, got %q", "1.0.0", sut.GitVersion)
+	}
+}
@@ -0,0 +1,438 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goversion
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// tagPattern matches the semver-ish tags considered by getGitDescribe,
+// e.g. "v1.2.3" or "1.2.3".
+var tagPattern = regexp.MustCompile(`^v?(\d+\.\d+\.\d+)$`)
+
+// WithGitDescribe computes GitVersion (and GitTag) as a
+// `git describe --tags`-style version of the repository at repoPath,
+// overriding whatever GetVersionInfo would otherwise derive from
+// debug.BuildInfo.
+func WithGitDescribe(repoPath string) Option {
+	return func(i *Info) {
+		i.gitDescribeEnabled = true
+		i.gitDescribeRepoPath = repoPath
+	}
+}
+
+// getGitDescribe computes a `git describe --tags`-style version from
+// the repository at repoPath, using bi.Settings' "vcs.revision" and
+// "vcs.modified" to identify HEAD and the dirty state, and returns it
+// together with the resolved tag (empty when none was resolved) and the
+// commit distance to that tag (0 when none was resolved). The distance is
+// the length of the shortest parent path from HEAD to the tagged commit;
+// across merges this can be lower than the count `git describe` reports,
+// which counts every commit not reachable from the tag. It walks the
+// repository's commit graph in pure Go rather than shelling out to git,
+// reading both loose and packed objects. When no tag is reachable it falls
+// back to "v0.0.0-0-g<shortsha>", and for a shallow clone (detected via
+// `.git/shallow`) - where history can't be walked at all - it falls back to
+// just "g<shortsha>".
+func getGitDescribe(bi *debug.BuildInfo, repoPath string) (string, string, int) {
+	revision := getKey(bi, "vcs.revision")
+	repo, err := openGitRepo(repoPath)
+	if err != nil {
+		return "", "", 0
+	}
+
+	head := revision
+	if head == "" {
+		head, err = repo.headCommit()
+		if err != nil {
+			return "", "", 0
+		}
+	}
+	shortHead := shortSHA(head)
+
+	suffix := ""
+	if getKey(bi, "vcs.modified") == "true" {
+		suffix = "+dirty"
+	}
+
+	if repo.isShallow() {
+		return "g" + shortHead + suffix, "", 0
+	}
+
+	tags, err := repo.tags()
+	if err != nil {
+		return "g" + shortHead + suffix, "", 0
+	}
+
+	tag, distance, err := repo.nearestTag(head, tags)
+	if err != nil {
+		// History couldn't be walked (corrupt or unreadable objects);
+		// be honest about what we actually know.
+		return "g" + shortHead + suffix, "", 0
+	}
+	if tag == "" {
+		return "v0.0.0-0-g" + shortHead + suffix, "", 0
+	}
+
+	return fmt.Sprintf("%s-%d-g%s%s", tag, distance, shortHead, suffix), tag, distance
+}
+
+// gitRepo is a minimal, read-only view of a local Git repository,
+// supporting only what getGitDescribe needs: resolving HEAD, listing
+// tags, and walking commit objects, whether loose or packed.
+type gitRepo struct {
+	gitDir string
+	packs  []*gitPack
+}
+
+// openGitRepo locates the .git directory for repoPath, following
+// worktree-style "gitdir:" redirects, and indexes any pack files present.
+func openGitRepo(repoPath string) (*gitRepo, error) {
+	dotGit := filepath.Join(repoPath, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return nil, err
+	}
+
+	var gitDir string
+	if info.IsDir() {
+		gitDir = dotGit
+	} else {
+		data, err := os.ReadFile(dotGit)
+		if err != nil {
+			return nil, err
+		}
+		line := strings.TrimSpace(string(data))
+		const prefix = "gitdir: "
+		if !strings.HasPrefix(line, prefix) {
+			return nil, fmt.Errorf("goversion: unrecognized .git file %q", dotGit)
+		}
+		gitDir = strings.TrimPrefix(line, prefix)
+		if !filepath.IsAbs(gitDir) {
+			gitDir = filepath.Join(repoPath, gitDir)
+		}
+	}
+
+	packs, err := loadGitPacks(gitDir)
+	if err != nil {
+		return nil, err
+	}
+	return &gitRepo{gitDir: gitDir, packs: packs}, nil
+}
+
+// isShallow reports whether the repository is a shallow clone, i.e. its
+// history is truncated and can't be fully walked.
+func (r *gitRepo) isShallow() bool {
+	_, err := os.Stat(filepath.Join(r.gitDir, "shallow"))
+	return err == nil
+}
+
+// headCommit resolves HEAD to a commit sha.
+func (r *gitRepo) headCommit() (string, error) {
+	data, err := os.ReadFile(filepath.Join(r.gitDir, "HEAD"))
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(data))
+	const prefix = "ref: "
+	if !strings.HasPrefix(line, prefix) {
+		return line, nil
+	}
+	return r.resolveRef(strings.TrimPrefix(line, prefix))
+}
+
+// resolveRef resolves a ref name (e.g. "refs/heads/main") to a commit sha,
+// checking loose refs first and falling back to packed-refs.
+func (r *gitRepo) resolveRef(name string) (string, error) {
+	if data, err := os.ReadFile(filepath.Join(r.gitDir, filepath.FromSlash(name))); err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	packed, err := r.packedRefs()
+	if err != nil {
+		return "", err
+	}
+	if sha, ok := packed[name]; ok {
+		return sha, nil
+	}
+	return "", fmt.Errorf("goversion: unknown ref %q", name)
+}
+
+// packedRefs parses the packed-refs file into ref name -> commit sha,
+// peeling annotated tags to the commit they point at.
+func (r *gitRepo) packedRefs() (map[string]string, error) {
+	f, err := os.Open(filepath.Join(r.gitDir, "packed-refs"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	refs := map[string]string{}
+	var lastRef string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "^"):
+			// Peeled sha of the previous annotated tag: prefer the commit
+			// it points to over the tag object's own sha.
+			refs[lastRef] = strings.TrimPrefix(line, "^")
+		default:
+			parts := strings.SplitN(line, " ", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			refs[parts[1]] = parts[0]
+			lastRef = parts[1]
+		}
+	}
+	return refs, scanner.Err()
+}
+
+// tags returns every tag name (without the "refs/tags/" prefix) mapped to
+// the commit sha it resolves to, skipping tag objects this reader doesn't
+// know how to peel.
+func (r *gitRepo) tags() (map[string]string, error) {
+	result := map[string]string{}
+
+	tagsDir := filepath.Join(r.gitDir, "refs", "tags")
+	_ = filepath.Walk(tagsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return nil
+		}
+		name, relErr := filepath.Rel(tagsDir, path)
+		if relErr != nil {
+			return nil
+		}
+		sha := strings.TrimSpace(string(data))
+		if commit, err := r.peelTag(sha); err == nil {
+			result[filepath.ToSlash(name)] = commit
+		}
+		return nil
+	})
+
+	packed, err := r.packedRefs()
+	if err != nil {
+		return result, err
+	}
+	for name, sha := range packed {
+		const prefix = "refs/tags/"
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if commit, err := r.peelTag(sha); err == nil {
+			result[strings.TrimPrefix(name, prefix)] = commit
+		}
+	}
+	return result, nil
+}
+
+// peelTag resolves sha to the commit it ultimately points at, following
+// annotated tag objects, whether they are stored loose or packed.
+func (r *gitRepo) peelTag(sha string) (string, error) {
+	for i := 0; i < 10; i++ {
+		typ, data, err := r.readObject(sha)
+		if err != nil {
+			return "", err
+		}
+		if typ == "commit" {
+			return sha, nil
+		}
+		if typ != "tag" {
+			return "", fmt.Errorf("goversion: unexpected object type %q for %q", typ, sha)
+		}
+		sha, err = parseTagObjectTarget(data)
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("goversion: tag chain too deep for %q", sha)
+}
+
+// parseTagObjectTarget extracts the "object <sha>" line from an annotated
+// tag object's body.
+func parseTagObjectTarget(data []byte) (string, error) {
+	for _, line := range strings.Split(string(data), "\n") {
+		if sha, ok := strings.CutPrefix(line, "object "); ok {
+			return strings.TrimSpace(sha), nil
+		}
+	}
+	return "", fmt.Errorf("goversion: no object line in tag body")
+}
+
+// readObject reads an object by sha, whether loose or packed (including
+// delta-compressed pack entries), returning its type and content.
+func (r *gitRepo) readObject(sha string) (string, []byte, error) {
+	typ, body, err := r.readLooseObject(sha)
+	if err == nil {
+		return typ, body, nil
+	}
+
+	for _, p := range r.packs {
+		if offset, ok := p.find(sha); ok {
+			return p.readAt(offset, r)
+		}
+	}
+
+	return "", nil, err
+}
+
+// readLooseObject reads a loose object (objects/xx/yyyy...) by sha.
+func (r *gitRepo) readLooseObject(sha string) (string, []byte, error) {
+	if len(sha) < 3 {
+		return "", nil, fmt.Errorf("goversion: invalid sha %q", sha)
+	}
+	path := filepath.Join(r.gitDir, "objects", sha[:2], sha[2:])
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return "", nil, err
+	}
+	defer zr.Close()
+
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return "", nil, err
+	}
+
+	header, body, ok := bytes.Cut(raw, []byte{0})
+	if !ok {
+		return "", nil, fmt.Errorf("goversion: malformed object %q", sha)
+	}
+	typ, _, ok := bytes.Cut(header, []byte(" "))
+	if !ok {
+		return "", nil, fmt.Errorf("goversion: malformed object header for %q", sha)
+	}
+	return string(typ), body, nil
+}
+
+// parents returns the parent commit shas of a commit object's body.
+func parents(body []byte) []string {
+	var out []string
+	for _, line := range strings.Split(string(body), "\n") {
+		if line == "" {
+			break
+		}
+		if sha, ok := strings.CutPrefix(line, "parent "); ok {
+			out = append(out, strings.TrimSpace(sha))
+		}
+	}
+	return out
+}
+
+// nearestTag walks the full commit graph from head - every parent of every
+// merge commit, not just the first-parent chain - breadth-first, returning
+// the semver-looking tag reached first (the highest version wins among tags
+// on that same commit) and its shortest distance from head. A first-parent-only walk would miss tags
+// reachable only through a merged-in branch, and would overstate the
+// distance to tags that a merge commit's other parent reaches more directly.
+func (r *gitRepo) nearestTag(head string, tags map[string]string) (string, int, error) {
+	commitToTags := map[string][]string{}
+	for name, sha := range tags {
+		if tagPattern.MatchString(name) {
+			commitToTags[sha] = append(commitToTags[sha], name)
+		}
+	}
+
+	type queued struct {
+		sha      string
+		distance int
+	}
+	visited := map[string]bool{head: true}
+	queue := []queued{{head, 0}}
+
+	for i := 0; i < len(queue); i++ {
+		if i >= 100000 {
+			return "", 0, fmt.Errorf("goversion: history walk exceeded limit from %q", head)
+		}
+		entry := queue[i]
+
+		if names, ok := commitToTags[entry.sha]; ok {
+			return bestTag(names), entry.distance, nil
+		}
+
+		typ, body, err := r.readObject(entry.sha)
+		if err != nil {
+			return "", 0, err
+		}
+		if typ != "commit" {
+			return "", 0, fmt.Errorf("goversion: %q is not a commit", entry.sha)
+		}
+		for _, p := range parents(body) {
+			if visited[p] {
+				continue
+			}
+			visited[p] = true
+			queue = append(queue, queued{p, entry.distance + 1})
+		}
+	}
+	return "", 0, nil
+}
+
+// bestTag picks the highest-precedence tag among those pointing at the
+// same commit.
+func bestTag(names []string) string {
+	best := names[0]
+	bestMajor, bestMinor, bestPatch := parseSemverTuple(best)
+	for _, name := range names[1:] {
+		major, minor, patch := parseSemverTuple(name)
+		if major > bestMajor || (major == bestMajor && minor > bestMinor) ||
+			(major == bestMajor && minor == bestMinor && patch > bestPatch) {
+			best, bestMajor, bestMinor, bestPatch = name, major, minor, patch
+		}
+	}
+	return best
+}
+
+// parseSemverTuple extracts major/minor/patch from a tag already known to
+// match tagPattern.
+func parseSemverTuple(tag string) (int, int, int) {
+	m := tagPattern.FindStringSubmatch(tag)
+	if m == nil {
+		return 0, 0, 0
+	}
+	parts := strings.SplitN(m[1], ".", 3)
+	major, _ := strconv.Atoi(parts[0])
+	minor, _ := strconv.Atoi(parts[1])
+	patch, _ := strconv.Atoi(parts[2])
+	return major, minor, patch
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goversion
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+// runGit runs git with args against dir, failing t on error.
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=goversion-test", "GIT_AUTHOR_EMAIL=goversion-test@example.com",
+		"GIT_COMMITTER_NAME=goversion-test", "GIT_COMMITTER_EMAIL=goversion-test@example.com",
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// writeAndCommit writes content to name in dir and commits it.
+func writeAndCommit(t *testing.T, dir, name, content, message string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "commit", "-q", "-m", message)
+}
+
+// initTaggedRepo creates a repo with v1.0.0 on its first commit and two
+// more commits on top, so "git describe" should read "v1.0.0-2-g<sha>".
+func initTaggedRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	writeAndCommit(t, dir, "f.txt", "a", "one")
+	runGit(t, dir, "tag", "v1.0.0")
+	writeAndCommit(t, dir, "f.txt", "b", "two")
+	writeAndCommit(t, dir, "f.txt", "c", "three")
+	return dir
+}
+
+func TestGetGitDescribeNoRepo(t *testing.T) {
+	version, tag, _ := getGitDescribe(&debug.BuildInfo{}, t.TempDir())
+	if version != "" || tag != "" {
+		t.Fatalf("expected empty results, got version %q tag %q", version, tag)
+	}
+}
+
+func TestGetGitDescribeCurrentRepo(t *testing.T) {
+	version, _, _ := getGitDescribe(&debug.BuildInfo{}, ".")
+	if version == "" {
+		t.Fatal("expected a non-empty describe string")
+	}
+	if !strings.HasPrefix(version, "v0.0.0-0-g") && !strings.HasPrefix(version, "g") && !strings.Contains(version, "-g") {
+		t.Fatalf("unexpected describe format: %q", version)
+	}
+}
+
+// TestGetGitDescribeLooseAndPacked is a regression test for a bug where
+// getGitDescribe only resolved tags reachable through loose objects: once
+// a repo had been `git gc`'d (the normal state of any checked-out clone),
+// every object became packed and the real tag was silently discarded.
+func TestGetGitDescribeLooseAndPacked(t *testing.T) {
+	dir := initTaggedRepo(t)
+	const wantPrefix = "v1.0.0-2-g"
+
+	version, tag, _ := getGitDescribe(&debug.BuildInfo{}, dir)
+	if !strings.HasPrefix(version, wantPrefix) {
+		t.Fatalf("before gc: expected a %q-prefixed version, got %q", wantPrefix, version)
+	}
+	if tag != "v1.0.0" {
+		t.Fatalf("before gc: expected GitTag %q, got %q", "v1.0.0", tag)
+	}
+
+	runGit(t, dir, "gc", "-q")
+
+	version, tag, _ = getGitDescribe(&debug.BuildInfo{}, dir)
+	if !strings.HasPrefix(version, wantPrefix) {
+		t.Fatalf("after gc: expected a %q-prefixed version from packed objects, got %q", wantPrefix, version)
+	}
+	if tag != "v1.0.0" {
+		t.Fatalf("after gc: expected GitTag %q, got %q", "v1.0.0", tag)
+	}
+}
+
+// TestGetGitDescribeMergedTag is a regression test for a bug where
+// nearestTag only followed parents[0]: a tag reachable solely through a
+// merged-in branch (an ordinary git-flow/PR shape) was invisible entirely,
+// since the first-parent chain from the merge commit never reaches it.
+func TestGetGitDescribeMergedTag(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	writeAndCommit(t, dir, "f.txt", "a", "one")
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	writeAndCommit(t, dir, "feature.txt", "a", "feature one")
+	runGit(t, dir, "tag", "v1.0.0")
+	writeAndCommit(t, dir, "feature.txt", "b", "feature two")
+
+	runGit(t, dir, "checkout", "-q", "main")
+	writeAndCommit(t, dir, "f.txt", "b", "two")
+	runGit(t, dir, "merge", "-q", "--no-ff", "-m", "merge feature", "feature")
+
+	const wantPrefix = "v1.0.0-2-g"
+	version, tag, _ := getGitDescribe(&debug.BuildInfo{}, dir)
+	if !strings.HasPrefix(version, wantPrefix) {
+		t.Fatalf("expected a %q-prefixed version, got %q", wantPrefix, version)
+	}
+	if tag != "v1.0.0" {
+		t.Fatalf("expected GitTag %q, got %q", "v1.0.0", tag)
+	}
+}
+
+func TestGetGitDescribeNoTags(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	writeAndCommit(t, dir, "f.txt", "a", "one")
+
+	version, tag, _ := getGitDescribe(&debug.BuildInfo{}, dir)
+	if !strings.HasPrefix(version, "v0.0.0-0-g") {
+		t.Fatalf("expected a v0.0.0-0-g prefixed version, got %q", version)
+	}
+	if tag != "" {
+		t.Fatalf("expected no GitTag when no tag is reachable, got %q", tag)
+	}
+}
+
+// TestGetGitDescribeShallow is a regression test: a repo with no fetched
+// history (simulated here via the `.git/shallow` marker a real shallow
+// clone leaves behind) must not be mistaken for "no tags reachable" and
+// fabricate a v0.0.0-0-g<sha> version; it should fall back to a bare
+// g<sha>, and GitTag must stay unset.
+func TestGetGitDescribeShallow(t *testing.T) {
+	dir := initTaggedRepo(t)
+	if err := os.WriteFile(filepath.Join(dir, ".git", "shallow"), []byte("deadbeefdeadbeefdeadbeefdeadbeefdeadbeef\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	version, tag, _ := getGitDescribe(&debug.BuildInfo{}, dir)
+	if strings.HasPrefix(version, "v1.0.0") {
+		t.Fatalf("expected the shallow marker to suppress tag resolution, got %q", version)
+	}
+	if !strings.HasPrefix(version, "g") {
+		t.Fatalf("expected a bare g<sha> fallback, got %q", version)
+	}
+	if tag != "" {
+		t.Fatalf("expected no GitTag for a shallow clone, got %q", tag)
+	}
+}
+
+func TestWithGitDescribe(t *testing.T) {
+	dir := initTaggedRepo(t)
+	sut := GetVersionInfo(WithGitDescribe(dir))
+	if !strings.HasPrefix(sut.GitVersion, "v1.0.0-2-g") {
+		t.Fatalf("expected GitVersion to resolve the tag, got %q", sut.GitVersion)
+	}
+	if sut.GitTag != "v1.0.0" {
+		t.Fatalf("expected GitTag %q, got %q", "v1.0.0", sut.GitTag)
+	}
+}
@@ -0,0 +1,42 @@
+//go:build goversion_otel
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goversion
+
+import "go.opentelemetry.io/otel/attribute"
+
+// ResourceAttributes renders Info as the OpenTelemetry resource attributes
+// conventionally used to identify a running service, per the semantic
+// conventions for service and VCS resources. Building with this method
+// requires the goversion_otel build tag.
+func (i Info) ResourceAttributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("service.version", i.GitVersion),
+		attribute.String("vcs.repository_commit", i.GitCommit),
+		attribute.String("vcs.repository_branch", i.GitBranch),
+		attribute.Bool("vcs.clean", i.GitTreeState == "clean"),
+		attribute.String("build.date", i.BuildDate),
+	}
+	if i.AppName != "" {
+		attrs = append(attrs, attribute.String("service.name", i.AppName))
+	}
+	if i.BuiltBy != "" {
+		attrs = append(attrs, attribute.String("build.builder", i.BuiltBy))
+	}
+	return attrs
+}
@@ -0,0 +1,39 @@
+//go:build goversion_otel
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goversion
+
+import "testing"
+
+func TestResourceAttributes(t *testing.T) {
+	sut := GetVersionInfo(WithAppDetails("test", "a test description", "https://carlosbecker.com"))
+	attrs := sut.ResourceAttributes()
+	if len(attrs) == 0 {
+		t.Fatal("expected at least one attribute")
+	}
+
+	found := false
+	for _, a := range attrs {
+		if string(a.Key) == "service.name" && a.Value.AsString() == "test" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected a service.name attribute set to \"test\"")
+	}
+}
@@ -0,0 +1,395 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goversion
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Pack object types, per the Git pack format.
+const (
+	packObjCommit   = 1
+	packObjTree     = 2
+	packObjBlob     = 3
+	packObjTag      = 4
+	packObjOfsDelta = 6
+	packObjRefDelta = 7
+)
+
+var packIdxMagic = []byte{0xff, 't', 'O', 'c'}
+
+// gitPack is a parsed version-2 pack index (.idx), lazily reading object
+// data from its companion .pack file on demand.
+type gitPack struct {
+	packPath string
+	shas     [][20]byte // sorted ascending, parallel to offsets
+	offsets  []uint64
+}
+
+// loadGitPacks indexes every .idx/.pack pair under gitDir/objects/pack.
+// Packs this reader can't parse (e.g. legacy v1 indexes) are skipped
+// rather than treated as fatal, since loose objects or other packs may
+// still resolve what's needed.
+func loadGitPacks(gitDir string) ([]*gitPack, error) {
+	packDir := filepath.Join(gitDir, "objects", "pack")
+	entries, err := os.ReadDir(packDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var packs []*gitPack
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".idx") {
+			continue
+		}
+		idxPath := filepath.Join(packDir, e.Name())
+		packPath := strings.TrimSuffix(idxPath, ".idx") + ".pack"
+		p, err := parsePackIndex(idxPath, packPath)
+		if err != nil {
+			continue
+		}
+		packs = append(packs, p)
+	}
+	return packs, nil
+}
+
+// parsePackIndex parses a version-2 pack index file.
+func parsePackIndex(idxPath, packPath string) (*gitPack, error) {
+	data, err := os.ReadFile(idxPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 8 || !bytes.Equal(data[:4], packIdxMagic) {
+		return nil, fmt.Errorf("goversion: %q is not a version-2 pack index", idxPath)
+	}
+	if version := binary.BigEndian.Uint32(data[4:8]); version != 2 {
+		return nil, fmt.Errorf("goversion: unsupported pack index version %d in %q", version, idxPath)
+	}
+
+	off := 8
+	var fanout [256]uint32
+	for i := range fanout {
+		fanout[i] = binary.BigEndian.Uint32(data[off : off+4])
+		off += 4
+	}
+	count := int(fanout[255])
+
+	shas := make([][20]byte, count)
+	for i := 0; i < count; i++ {
+		copy(shas[i][:], data[off:off+20])
+		off += 20
+	}
+
+	off += count * 4 // skip CRC32 checksums, unused by this reader
+
+	offsetsOff := off
+	off += count * 4
+	bigOffsetsOff := off
+
+	offsets := make([]uint64, count)
+	for i := 0; i < count; i++ {
+		raw := binary.BigEndian.Uint32(data[offsetsOff+i*4 : offsetsOff+i*4+4])
+		if raw&0x80000000 == 0 {
+			offsets[i] = uint64(raw)
+			continue
+		}
+		bigIdx := int(raw &^ 0x80000000)
+		offsets[i] = binary.BigEndian.Uint64(data[bigOffsetsOff+bigIdx*8 : bigOffsetsOff+bigIdx*8+8])
+	}
+
+	return &gitPack{packPath: packPath, shas: shas, offsets: offsets}, nil
+}
+
+// find looks up sha's offset within the pack, via binary search over the
+// index's sorted sha table.
+func (p *gitPack) find(sha string) (uint64, bool) {
+	want, err := hex.DecodeString(sha)
+	if err != nil || len(want) != 20 {
+		return 0, false
+	}
+
+	lo, hi := 0, len(p.shas)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch bytes.Compare(p.shas[mid][:], want) {
+		case 0:
+			return p.offsets[mid], true
+		case -1:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return 0, false
+}
+
+// readAt decodes the object stored at offset in the pack, resolving
+// OFS_DELTA/REF_DELTA chains as needed. repo is used to resolve
+// REF_DELTA base objects, which may live in a different pack or loose.
+func (p *gitPack) readAt(offset uint64, repo *gitRepo) (string, []byte, error) {
+	f, err := os.Open(p.packPath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(int64(offset), io.SeekStart); err != nil {
+		return "", nil, err
+	}
+	return p.decodeObject(bufio.NewReader(f), offset, repo)
+}
+
+// decodeObject reads one pack object header, starting at offset in the
+// pack file (via br), and returns its resolved type and content.
+func (p *gitPack) decodeObject(br *bufio.Reader, offset uint64, repo *gitRepo) (string, []byte, error) {
+	typ, err := readPackObjectHeader(br)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch typ {
+	case packObjCommit, packObjTree, packObjBlob, packObjTag:
+		data, err := inflate(br)
+		return packObjectTypeName(typ), data, err
+
+	case packObjOfsDelta:
+		baseDistance, err := readOffsetDelta(br)
+		if err != nil {
+			return "", nil, err
+		}
+		deltaData, err := inflate(br)
+		if err != nil {
+			return "", nil, err
+		}
+		if baseDistance > offset {
+			return "", nil, fmt.Errorf("goversion: OFS_DELTA base offset underflow at %d", offset)
+		}
+		baseType, baseData, err := p.readAt(offset-baseDistance, repo)
+		if err != nil {
+			return "", nil, err
+		}
+		result, err := applyDelta(baseData, deltaData)
+		return baseType, result, err
+
+	case packObjRefDelta:
+		var baseSHA [20]byte
+		if _, err := io.ReadFull(br, baseSHA[:]); err != nil {
+			return "", nil, err
+		}
+		deltaData, err := inflate(br)
+		if err != nil {
+			return "", nil, err
+		}
+		baseType, baseData, err := repo.readObject(hex.EncodeToString(baseSHA[:]))
+		if err != nil {
+			return "", nil, err
+		}
+		result, err := applyDelta(baseData, deltaData)
+		return baseType, result, err
+
+	default:
+		return "", nil, fmt.Errorf("goversion: unsupported pack object type %d", typ)
+	}
+}
+
+// packObjectTypeName renders a pack object type as the string form used
+// by readObject/readLooseObject.
+func packObjectTypeName(typ int) string {
+	switch typ {
+	case packObjCommit:
+		return "commit"
+	case packObjTree:
+		return "tree"
+	case packObjBlob:
+		return "blob"
+	case packObjTag:
+		return "tag"
+	default:
+		return ""
+	}
+}
+
+// readPackObjectHeader reads a pack object's variable-length type+size
+// header, returning the type (size is implied by the zlib stream and not
+// needed by this reader).
+func readPackObjectHeader(r io.ByteReader) (int, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	typ := int((b >> 4) & 0x7)
+	for b&0x80 != 0 {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+	return typ, nil
+}
+
+// readOffsetDelta reads the OFS_DELTA "negative offset" varint: each byte
+// contributes 7 bits, most-significant-byte first, with a "+1" added at
+// every continuation (see Git's pack-format documentation).
+func readOffsetDelta(r io.ByteReader) (uint64, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	value := uint64(b & 0x7f)
+	for b&0x80 != 0 {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+		value = ((value + 1) << 7) | uint64(b&0x7f)
+	}
+	return value, nil
+}
+
+// inflate zlib-decompresses the entirety of r.
+func inflate(r io.Reader) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// readDeltaSize reads a delta stream's size varint: 7 bits per byte,
+// least-significant-byte first.
+func readDeltaSize(r *bytes.Reader) (uint64, error) {
+	var size uint64
+	var shift uint
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		size |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return size, nil
+		}
+		shift += 7
+	}
+}
+
+// applyDelta reconstructs an object from its delta-compressed
+// representation against base, per Git's pack delta format.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	r := bytes.NewReader(delta)
+
+	srcSize, err := readDeltaSize(r)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(base)) != srcSize {
+		return nil, fmt.Errorf("goversion: delta base size mismatch: want %d, got %d", srcSize, len(base))
+	}
+
+	dstSize, err := readDeltaSize(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dst := make([]byte, 0, dstSize)
+	for r.Len() > 0 {
+		op, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		if op&0x80 != 0 {
+			cpOff, cpSize, err := readCopyInstruction(r, op)
+			if err != nil {
+				return nil, err
+			}
+			if uint64(cpOff)+uint64(cpSize) > uint64(len(base)) {
+				return nil, fmt.Errorf("goversion: delta copy instruction out of range")
+			}
+			dst = append(dst, base[cpOff:cpOff+cpSize]...)
+			continue
+		}
+
+		if op == 0 {
+			return nil, fmt.Errorf("goversion: reserved delta opcode 0")
+		}
+		buf := make([]byte, op)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		dst = append(dst, buf...)
+	}
+
+	if uint64(len(dst)) != dstSize {
+		return nil, fmt.Errorf("goversion: delta result size mismatch: want %d, got %d", dstSize, len(dst))
+	}
+	return dst, nil
+}
+
+// readCopyInstruction decodes a delta "copy" instruction's offset and
+// size, whose encoded byte layout is selected by op's low 7 bits.
+func readCopyInstruction(r *bytes.Reader, op byte) (uint32, uint32, error) {
+	readOptional := func(present bool, shift uint, acc *uint32) error {
+		if !present {
+			return nil
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		*acc |= uint32(b) << shift
+		return nil
+	}
+
+	var cpOff, cpSize uint32
+	if err := readOptional(op&0x01 != 0, 0, &cpOff); err != nil {
+		return 0, 0, err
+	}
+	if err := readOptional(op&0x02 != 0, 8, &cpOff); err != nil {
+		return 0, 0, err
+	}
+	if err := readOptional(op&0x04 != 0, 16, &cpOff); err != nil {
+		return 0, 0, err
+	}
+	if err := readOptional(op&0x08 != 0, 24, &cpOff); err != nil {
+		return 0, 0, err
+	}
+	if err := readOptional(op&0x10 != 0, 0, &cpSize); err != nil {
+		return 0, 0, err
+	}
+	if err := readOptional(op&0x20 != 0, 8, &cpSize); err != nil {
+		return 0, 0, err
+	}
+	if err := readOptional(op&0x40 != 0, 16, &cpSize); err != nil {
+		return 0, 0, err
+	}
+	if cpSize == 0 {
+		cpSize = 0x10000
+	}
+	return cpOff, cpSize, nil
+}
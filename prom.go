@@ -0,0 +1,53 @@
+//go:build goversion_prom
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goversion
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusLabels renders Info as the label set used by
+// RegisterBuildInfoCollector. Building with this method requires the
+// goversion_prom build tag.
+func (i Info) PrometheusLabels() prometheus.Labels {
+	return prometheus.Labels{
+		"version":    i.GitVersion,
+		"commit":     i.GitCommit,
+		"branch":     i.GitBranch,
+		"dirty":      strconv.FormatBool(i.GitTreeState == "dirty"),
+		"built_by":   i.BuiltBy,
+		"go_version": i.GoVersion,
+	}
+}
+
+// RegisterBuildInfoCollector installs a build_info{version, commit,
+// branch, dirty, built_by, go_version} gauge, permanently set to 1, onto
+// reg, mirroring how Helm and other kube-ecosystem projects expose build
+// info. Building with this method requires the goversion_prom build tag.
+func (i Info) RegisterBuildInfoCollector(reg prometheus.Registerer) error {
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "build_info",
+		Help:        "A metric with a constant '1' value labeled by version, commit, branch, dirty, built_by and go_version from which the binary was built.",
+		ConstLabels: i.PrometheusLabels(),
+	})
+	gauge.Set(1)
+	return reg.Register(gauge)
+}
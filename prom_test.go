@@ -0,0 +1,44 @@
+//go:build goversion_prom
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goversion
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRegisterBuildInfoCollector(t *testing.T) {
+	sut := GetVersionInfo(func(i *Info) {
+		i.GitVersion = "1.0.0"
+	})
+
+	reg := prometheus.NewRegistry()
+	if err := sut.RegisterBuildInfoCollector(reg); err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	metrics, err := reg.Gather()
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("expected exactly one metric family, got %d", len(metrics))
+	}
+}
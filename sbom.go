@@ -0,0 +1,187 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+)
+
+// ModuleInfo describes a single resolved dependency module, mirroring
+// debug.Module.
+type ModuleInfo struct {
+	Path    string      `json:"path"`
+	Version string      `json:"version"`
+	Sum     string      `json:"sum,omitempty"`
+	Replace *ModuleInfo `json:"replace,omitempty"`
+}
+
+// moduleInfos converts bi.Deps into the module list exposed on Info.
+func moduleInfos(bi *debug.BuildInfo) []ModuleInfo {
+	if bi == nil || len(bi.Deps) == 0 {
+		return nil
+	}
+
+	modules := make([]ModuleInfo, len(bi.Deps))
+	for idx, dep := range bi.Deps {
+		modules[idx] = moduleInfoFrom(dep)
+	}
+	return modules
+}
+
+// moduleInfoFrom converts a single *debug.Module, following at most one
+// level of "replace" since that's all the Go toolchain records.
+func moduleInfoFrom(m *debug.Module) ModuleInfo {
+	if m == nil {
+		return ModuleInfo{}
+	}
+	info := ModuleInfo{Path: m.Path, Version: m.Version, Sum: m.Sum}
+	if m.Replace != nil {
+		replace := moduleInfoFrom(m.Replace)
+		info.Replace = &replace
+	}
+	return info
+}
+
+// buildSettings converts bi.Settings into the map exposed on Info.
+func buildSettings(bi *debug.BuildInfo) map[string]string {
+	if bi == nil || len(bi.Settings) == 0 {
+		return nil
+	}
+
+	settings := make(map[string]string, len(bi.Settings))
+	for _, s := range bi.Settings {
+		settings[s.Key] = s.Value
+	}
+	return settings
+}
+
+// purl renders the pkg:golang purl for a module path and version, per
+// https://github.com/package-url/purl-spec.
+func purl(path, version string) string {
+	if path == "" {
+		return ""
+	}
+	if version == "" {
+		return "pkg:golang/" + path
+	}
+	return fmt.Sprintf("pkg:golang/%s@%s", path, version)
+}
+
+// cyclonedxComponent is a minimal CycloneDX 1.5 component.
+type cyclonedxComponent struct {
+	Type    string `json:"type"`
+	BOMRef  string `json:"bom-ref,omitempty"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+// cyclonedxBOM is a minimal CycloneDX 1.5 BOM document, covering only the
+// fields this package can populate from debug.BuildInfo.
+type cyclonedxBOM struct {
+	BOMFormat   string `json:"bomFormat"`
+	SpecVersion string `json:"specVersion"`
+	Version     int    `json:"version"`
+	Metadata    struct {
+		Component cyclonedxComponent `json:"component"`
+	} `json:"metadata"`
+	Components []cyclonedxComponent `json:"components,omitempty"`
+}
+
+// CycloneDXJSON renders Info and its Modules as a minimal CycloneDX 1.5
+// BOM document.
+func (i Info) CycloneDXJSON() ([]byte, error) {
+	name := firstNonEmpty(i.AppName, unknown)
+
+	var bom cyclonedxBOM
+	bom.BOMFormat = "CycloneDX"
+	bom.SpecVersion = "1.5"
+	bom.Version = 1
+	bom.Metadata.Component = cyclonedxComponent{
+		Type:    "application",
+		BOMRef:  purl(name, i.GitVersion),
+		Name:    name,
+		Version: i.GitVersion,
+		PURL:    purl(name, i.GitVersion),
+	}
+
+	for _, m := range i.Modules {
+		bom.Components = append(bom.Components, cyclonedxComponent{
+			Type:    "library",
+			BOMRef:  purl(m.Path, m.Version),
+			Name:    m.Path,
+			Version: m.Version,
+			PURL:    purl(m.Path, m.Version),
+		})
+	}
+
+	return json.MarshalIndent(bom, "", "  ")
+}
+
+// slsaResolvedDependency is a minimal in-toto ResourceDescriptor.
+type slsaResolvedDependency struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// slsaProvenance is a minimal SLSA v1 provenance statement, covering only
+// the fields this package can populate from debug.BuildInfo.
+type slsaProvenance struct {
+	Type          string `json:"_type"`
+	PredicateType string `json:"predicateType"`
+	Subject       []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+	Predicate struct {
+		BuildDefinition struct {
+			BuildType            string                   `json:"buildType"`
+			ExternalParameters   map[string]string        `json:"externalParameters,omitempty"`
+			ResolvedDependencies []slsaResolvedDependency `json:"resolvedDependencies,omitempty"`
+		} `json:"buildDefinition"`
+	} `json:"predicate"`
+}
+
+// SLSAProvenance renders Info as a minimal in-toto SLSA v1 provenance
+// statement.
+func (i Info) SLSAProvenance() ([]byte, error) {
+	var statement slsaProvenance
+	statement.Type = "https://in-toto.io/Statement/v1"
+	statement.PredicateType = "https://slsa.dev/provenance/v1"
+	statement.Subject = []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	}{{
+		Name:   firstNonEmpty(i.AppName, unknown),
+		Digest: map[string]string{"gitCommit": i.GitCommit},
+	}}
+	statement.Predicate.BuildDefinition.BuildType = "https://go.dev/build"
+	statement.Predicate.BuildDefinition.ExternalParameters = i.BuildSettings
+
+	for _, m := range i.Modules {
+		dep := slsaResolvedDependency{URI: purl(m.Path, m.Version)}
+		if m.Sum != "" {
+			dep.Digest = map[string]string{"gosum": m.Sum}
+		}
+		statement.Predicate.BuildDefinition.ResolvedDependencies = append(
+			statement.Predicate.BuildDefinition.ResolvedDependencies, dep)
+	}
+
+	return json.MarshalIndent(statement, "", "  ")
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goversion
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func newTestInfo() Info {
+	return GetVersionInfo(
+		WithAppDetails("test", "a test description", "https://carlosbecker.com"),
+		func(i *Info) {
+			i.GitVersion = "1.0.0"
+			i.GitCommit = "02af8e0619ca3f625bfbc25e60289e0eba222c35"
+			i.Modules = []ModuleInfo{
+				{Path: "github.com/example/dep", Version: "v1.2.3", Sum: "h1:abc="},
+			}
+			i.BuildSettings = map[string]string{"GOOS": "linux", "GOARCH": "amd64"}
+		},
+	)
+}
+
+func TestCycloneDXJSON(t *testing.T) {
+	sut := newTestInfo()
+	data, err := sut.CycloneDXJSON()
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	var bom map[string]any
+	if err := json.Unmarshal(data, &bom); err != nil {
+		t.Fatal("expected valid JSON, got error", err)
+	}
+	if bom["bomFormat"] != "CycloneDX" {
+		t.Fatalf("expected bomFormat CycloneDX, got %v", bom["bomFormat"])
+	}
+	if !strings.Contains(string(data), "github.com/example/dep") {
+		t.Fatal("expected the dependency to be listed as a component")
+	}
+}
+
+func TestSLSAProvenance(t *testing.T) {
+	sut := newTestInfo()
+	data, err := sut.SLSAProvenance()
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	var statement map[string]any
+	if err := json.Unmarshal(data, &statement); err != nil {
+		t.Fatal("expected valid JSON, got error", err)
+	}
+	if statement["predicateType"] != "https://slsa.dev/provenance/v1" {
+		t.Fatalf("unexpected predicateType %v", statement["predicateType"])
+	}
+	if !strings.Contains(string(data), "https://go.dev/build") {
+		t.Fatal("expected buildType to be set")
+	}
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goversion
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed, comparable form of Info.GitVersion.
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 string
+	Build               string
+	// IsPseudo reports whether GitVersion was a Go pseudo-version
+	// (v0.0.0-YYYYMMDDHHMMSS-abcdef123456). Pre holds the embedded
+	// timestamp in that case, so two pseudo-versions compare
+	// chronologically.
+	IsPseudo bool
+}
+
+var (
+	semverPattern        = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+([0-9A-Za-z.-]+))?$`)
+	pseudoVersionPattern = regexp.MustCompile(`^(\d{14})-([0-9a-f]{12})$`)
+)
+
+// Semver parses Info.GitVersion into a Version, understanding plain
+// SemVer ("1.2.3", "v1.2.3-RC+sha"), Go-style pseudo-versions
+// ("v0.0.0-20210101120000-abcdef123456"), and the "-RC+sha"/"-M+sha"/
+// "-SNAPSHOT+sha" forms produced by GitFlowStrategy.
+func (i Info) Semver() (Version, error) {
+	raw := strings.TrimPrefix(i.GitVersion, "v")
+
+	m := semverPattern.FindStringSubmatch(raw)
+	if m == nil {
+		return Version{}, fmt.Errorf("goversion: %q is not a valid version", i.GitVersion)
+	}
+
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+	v := Version{Major: major, Minor: minor, Patch: patch, Pre: m[4], Build: m[5]}
+
+	if pm := pseudoVersionPattern.FindStringSubmatch(m[4]); pm != nil {
+		v.IsPseudo = true
+		v.Pre = pm[1]
+		v.Build = pm[2]
+	}
+
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1 if v is respectively lower than, equal to or
+// higher than other, following SemVer precedence. Two pseudo-versions
+// with matching Major/Minor/Patch are ordered by their embedded
+// timestamp. Among GitFlowStrategy suffixes, SNAPSHOT < M < RC < release.
+func (v Version) Compare(other Version) int {
+	if c := cmpInt(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := cmpInt(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := cmpInt(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+	if v.IsPseudo && other.IsPseudo {
+		return strings.Compare(v.Pre, other.Pre)
+	}
+	return comparePre(v.Pre, other.Pre)
+}
+
+// compareCore compares only Major/Minor/Patch, ignoring Pre/Build. It
+// backs Info.Satisfies, where constraints are plain numeric ranges.
+func (v Version) compareCore(other Version) int {
+	if c := cmpInt(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := cmpInt(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	return cmpInt(v.Patch, other.Patch)
+}
+
+// prerank orders the pre-release labels produced by GitFlowStrategy; an
+// empty Pre (a release) always sorts highest. Unrecognized pre-release
+// strings sort below every known label.
+func prerank(pre string) int {
+	switch pre {
+	case "":
+		return 3
+	case "SNAPSHOT":
+		return 0
+	case "M":
+		return 1
+	case "RC":
+		return 2
+	default:
+		return -1
+	}
+}
+
+// comparePre orders two pre-release labels using prerank, falling back to
+// a lexicographic compare within the same rank.
+func comparePre(a, b string) int {
+	if ra, rb := prerank(a), prerank(b); ra != rb {
+		return cmpInt(ra, rb)
+	}
+	return strings.Compare(a, b)
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// constraintClause is a single normalized ">=1.2.3"-style comparison.
+type constraintClause struct {
+	op      string
+	version Version
+}
+
+func (c constraintClause) matches(v Version) bool {
+	cmp := v.compareCore(c.version)
+	switch c.op {
+	case ">=":
+		return cmp >= 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	case "==", "=":
+		return cmp == 0
+	default:
+		return false
+	}
+}
+
+// Satisfies reports whether Info.GitVersion satisfies constraint, an
+// npm/Composer-style constraint string such as ">=1.2.0 <2", "~1.2" or
+// "^1.2.3". Space-separated clauses are ANDed together.
+func (i Info) Satisfies(constraint string) (bool, error) {
+	v, err := i.Semver()
+	if err != nil {
+		return false, err
+	}
+
+	clauses, err := parseConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range clauses {
+		if !c.matches(v) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// parseConstraint splits constraint on whitespace and normalizes each
+// token into one or more constraintClauses, expanding "~" and "^" ranges
+// into an ">=" lower bound and a "<" upper bound.
+func parseConstraint(constraint string) ([]constraintClause, error) {
+	fields := strings.Fields(constraint)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("goversion: empty constraint")
+	}
+
+	var clauses []constraintClause
+	for _, tok := range fields {
+		op, rest := splitOp(tok)
+		switch op {
+		case "~":
+			lo, hi, err := tildeRange(rest)
+			if err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, constraintClause{op: ">=", version: lo}, constraintClause{op: "<", version: hi})
+		case "^":
+			lo, hi, err := caretRange(rest)
+			if err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, constraintClause{op: ">=", version: lo}, constraintClause{op: "<", version: hi})
+		default:
+			v, _, err := parsePartialVersion(rest)
+			if err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, constraintClause{op: op, version: v})
+		}
+	}
+	return clauses, nil
+}
+
+// splitOp peels a leading comparison operator off tok, defaulting to
+// "==" when none is present.
+func splitOp(tok string) (string, string) {
+	for _, op := range []string{">=", "<=", "==", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(tok, op) {
+			return op, strings.TrimSpace(strings.TrimPrefix(tok, op))
+		}
+	}
+	return "==", tok
+}
+
+// parsePartialVersion parses a possibly-partial dotted version ("1",
+// "1.2" or "1.2.3"), returning how many components were given.
+func parsePartialVersion(s string) (Version, int, error) {
+	s = strings.TrimPrefix(s, "v")
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return Version{}, 0, fmt.Errorf("goversion: invalid version %q in constraint", s)
+	}
+
+	var nums [3]int
+	for idx, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return Version{}, 0, fmt.Errorf("goversion: invalid version %q in constraint: %w", s, err)
+		}
+		nums[idx] = n
+	}
+	return Version{Major: nums[0], Minor: nums[1], Patch: nums[2]}, len(parts), nil
+}
+
+// tildeRange computes the [lo, hi) range of a "~" constraint: patch-level
+// changes are allowed if a minor version is specified, and minor-level
+// changes are allowed if not.
+func tildeRange(s string) (Version, Version, error) {
+	v, parts, err := parsePartialVersion(s)
+	if err != nil {
+		return Version{}, Version{}, err
+	}
+
+	if parts == 1 {
+		return v, Version{Major: v.Major + 1}, nil
+	}
+	return v, Version{Major: v.Major, Minor: v.Minor + 1}, nil
+}
+
+// caretRange computes the [lo, hi) range of a "^" constraint: changes
+// that don't modify the left-most non-zero component are allowed. A
+// component that was never given (e.g. the minor/patch in "^0") is a
+// wildcard, not a pinned zero, so it's parts - not just the value - that
+// decides which component bounds the range.
+func caretRange(s string) (Version, Version, error) {
+	v, parts, err := parsePartialVersion(s)
+	if err != nil {
+		return Version{}, Version{}, err
+	}
+
+	switch {
+	case v.Major > 0, parts == 1:
+		return v, Version{Major: v.Major + 1}, nil
+	case parts == 2, v.Minor > 0:
+		return v, Version{Minor: v.Minor + 1}, nil
+	default:
+		return v, Version{Patch: v.Patch + 1}, nil
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goversion
+
+import "testing"
+
+func infoWithVersion(version string) Info {
+	return GetVersionInfo(func(i *Info) {
+		i.GitVersion = version
+	})
+}
+
+func TestSemver(t *testing.T) {
+	t.Run("plain", func(t *testing.T) {
+		v, err := infoWithVersion("v1.2.3").Semver()
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		if v.Major != 1 || v.Minor != 2 || v.Patch != 3 {
+			t.Fatalf("unexpected version %+v", v)
+		}
+	})
+
+	t.Run("gitflow RC", func(t *testing.T) {
+		v, err := infoWithVersion("1.0.0-RC+02af8e0").Semver()
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		if v.Pre != "RC" || v.Build != "02af8e0" {
+			t.Fatalf("unexpected version %+v", v)
+		}
+	})
+
+	t.Run("pseudo version", func(t *testing.T) {
+		v, err := infoWithVersion("v0.0.0-20210101120000-abcdef123456").Semver()
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		if !v.IsPseudo {
+			t.Fatal("expected IsPseudo to be true")
+		}
+		if v.Pre != "20210101120000" || v.Build != "abcdef123456" {
+			t.Fatalf("unexpected version %+v", v)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := infoWithVersion("not-a-version").Semver(); err == nil {
+			t.Fatal("expected an error, got none")
+		}
+	})
+}
+
+func TestVersionCompare(t *testing.T) {
+	mustSemver := func(version string) Version {
+		v, err := infoWithVersion(version).Semver()
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %v", version, err)
+		}
+		return v
+	}
+
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.4", -1},
+		{"2.0.0", "1.9.9", 1},
+		{"1.0.0-SNAPSHOT+abc", "1.0.0-RC+abc", -1},
+		{"1.0.0-RC+abc", "1.0.0-M+abc", 1},
+		{"1.0.0-RC+abc", "1.0.0", -1},
+		{"v0.0.0-20210101120000-abcdef123456", "v0.0.0-20220101120000-abcdef123456", -1},
+	}
+	for _, c := range cases {
+		if got := mustSemver(c.a).Compare(mustSemver(c.b)); got != c.want {
+			t.Fatalf("Compare(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	cases := []struct {
+		version    string
+		constraint string
+		want       bool
+	}{
+		{"1.5.0", ">=1.2.0 <2", true},
+		{"2.0.0", ">=1.2.0 <2", false},
+		{"1.2.5", "~1.2", true},
+		{"1.3.0", "~1.2", false},
+		{"1.5.0", "^1.2.3", true},
+		{"2.0.0", "^1.2.3", false},
+		{"0.2.5", "^0.2.3", true},
+		{"0.3.0", "^0.2.3", false},
+		{"0.5.0", "^0", true},
+		{"1.0.0", "^0", false},
+		{"0.0.5", "^0.0", true},
+		{"0.1.0", "^0.0", false},
+	}
+
+	for _, c := range cases {
+		got, err := infoWithVersion(c.version).Satisfies(c.constraint)
+		if err != nil {
+			t.Fatalf("Satisfies(%q) on %q: unexpected error %v", c.constraint, c.version, err)
+		}
+		if got != c.want {
+			t.Fatalf("%q satisfies %q = %v, want %v", c.version, c.constraint, got, c.want)
+		}
+	}
+
+	t.Run("invalid constraint", func(t *testing.T) {
+		if _, err := infoWithVersion("1.0.0").Satisfies("not a constraint !!"); err == nil {
+			t.Fatal("expected an error, got none")
+		}
+	})
+}
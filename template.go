@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goversion
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateContext is the data made available to templates registered via
+// WithTemplate and rendered by Info.Render. It is meant to make it easy to
+// assemble `-ldflags` strings such as
+// `-X pkg.Version={{.Git.Tag}} -X pkg.Commit={{.Git.ShortCommit}}` without
+// having to shell out to git from build tooling.
+type TemplateContext struct {
+	// Env holds the process environment, as returned by os.Environ.
+	Env map[string]string
+	// Date mirrors Git.CommitDate, formatted as RFC3339. It is empty when
+	// no VCS commit time is available.
+	Date string
+	// Timestamp mirrors Git.CommitTimestamp. It is zero when no VCS commit
+	// time is available.
+	Timestamp int64
+	// Git holds the VCS metadata available at build time.
+	Git GitContext
+}
+
+// GitContext is the VCS-specific portion of a TemplateContext.
+type GitContext struct {
+	Branch          string
+	Tag             string
+	ShortCommit     string
+	FullCommit      string
+	CommitDate      string
+	CommitTimestamp int64
+	IsDirty         bool
+	IsClean         bool
+	Summary         string
+}
+
+// Render parses tmpl as a text/template and executes it against the
+// Info's TemplateContext.
+func (i Info) Render(tmpl string) (string, error) {
+	t, err := template.New("goversion").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, i.templateContext()); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// templateContext builds the TemplateContext derived from this Info.
+func (i Info) templateContext() TemplateContext {
+	commitDate, commitTimestamp := parseBuildDate(i.BuildDate)
+
+	shortCommit := i.GitCommit
+	if len(shortCommit) > 7 {
+		shortCommit = shortCommit[:7]
+	}
+
+	return TemplateContext{
+		Env:       environMap(),
+		Date:      commitDate,
+		Timestamp: commitTimestamp,
+		Git: GitContext{
+			Branch:          i.GitBranch,
+			Tag:             firstNonEmpty(i.GitTag, i.GitVersion),
+			ShortCommit:     shortCommit,
+			FullCommit:      i.GitCommit,
+			CommitDate:      i.BuildDate,
+			CommitTimestamp: commitTimestamp,
+			IsDirty:         i.GitTreeState == "dirty",
+			IsClean:         i.GitTreeState == "clean",
+			Summary:         strings.TrimSuffix(strings.TrimSpace(i.GitBranch+"-"+shortCommit), "-"),
+		},
+	}
+}
+
+// environMap turns os.Environ into a map, as expected by TemplateContext.
+func environMap() map[string]string {
+	env := os.Environ()
+	m := make(map[string]string, len(env))
+	for _, kv := range env {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		m[k] = v
+	}
+	return m
+}
+
+// parseBuildDate parses a date formatted like getBuildDate produces it,
+// returning its RFC3339 representation and Unix timestamp. It returns
+// ("", 0) when date is empty or invalid, rather than substituting the
+// current time, so templates can tell a missing VCS commit time apart
+// from a real one.
+func parseBuildDate(date string) (string, int64) {
+	if date == "" {
+		return "", 0
+	}
+	t, err := time.Parse("2006-01-02T15:04:05", date)
+	if err != nil {
+		return "", 0
+	}
+	return t.Format(time.RFC3339), t.Unix()
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goversion
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	sut := GetVersionInfo(func(i *Info) {
+		i.GitVersion = "1.2.3"
+		i.GitCommit = "02af8e0619ca3f625bfbc25e60289e0eba222c35"
+		i.GitBranch = "main"
+		i.GitTreeState = "clean"
+	})
+
+	t.Run("git fields", func(t *testing.T) {
+		got, err := sut.Render("{{.Git.Tag}}-{{.Git.ShortCommit}}")
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		want := "1.2.3-02af8e0"
+		if got != want {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("env", func(t *testing.T) {
+		got, err := sut.Render("{{.Env.HOME}}")
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		t.Log(got)
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		if _, err := sut.Render("{{.Nope"); err == nil {
+			t.Fatal("expected an error, got none")
+		}
+	})
+}
+
+// TestTemplateContextDateWithoutBuildDate is a regression test: Date and
+// Timestamp used to fall back to time.Now() when no VCS commit time was
+// available, making them nondeterministic. They must instead stay empty.
+func TestTemplateContextDateWithoutBuildDate(t *testing.T) {
+	sut := GetVersionInfo(func(i *Info) {
+		i.GitVersion = "1.2.3"
+		i.BuildDate = ""
+	})
+
+	ctx := sut.templateContext()
+	if ctx.Date != "" || ctx.Timestamp != 0 {
+		t.Fatalf("expected empty Date/Timestamp, got %q/%d", ctx.Date, ctx.Timestamp)
+	}
+	if ctx.Git.CommitDate != "" || ctx.Git.CommitTimestamp != 0 {
+		t.Fatalf("expected empty Git.CommitDate/CommitTimestamp, got %q/%d", ctx.Git.CommitDate, ctx.Git.CommitTimestamp)
+	}
+}
+
+func TestWithTemplate(t *testing.T) {
+	sut := GetVersionInfo(
+		WithTemplate("ldflags", "-X pkg.Version={{.Git.Tag}}"),
+		func(i *Info) {
+			i.GitVersion = "1.2.3"
+		},
+	)
+
+	want := "-X pkg.Version=1.2.3"
+	got, ok := sut.RenderedTemplates["ldflags"]
+	if !ok {
+		t.Fatal("expected a rendered \"ldflags\" template")
+	}
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	json, err := sut.JSONString()
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if string(json) == "" {
+		t.Fatal("should not be empty")
+	}
+}
@@ -0,0 +1,310 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package goversion provides a reusable way of collecting and exposing the
+// version, build and VCS metadata of a Go binary, in the style of the
+// `kubectl version` / `--version` banners shared across many CLIs.
+package goversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// unknown is used whenever a piece of version information could not be
+// determined.
+const unknown = "unknown"
+
+// Info holds the version, build and VCS metadata collected by
+// GetVersionInfo.
+type Info struct {
+	ASCIIName      string `json:"asciiName,omitempty"`
+	AppName        string `json:"appName,omitempty"`
+	AppDescription string `json:"appDescription,omitempty"`
+	URL            string `json:"url,omitempty"`
+	BuiltBy        string `json:"builtBy,omitempty"`
+
+	GitVersion   string `json:"gitVersion"`
+	GitTag       string `json:"gitTag,omitempty"`
+	GitCommit    string `json:"gitCommit"`
+	GitTreeState string `json:"gitTreeState"`
+	GitBranch    string `json:"gitBranch"`
+	BuildDate    string `json:"buildDate"`
+	GoVersion    string `json:"goVersion"`
+	Compiler     string `json:"compiler"`
+	Platform     string `json:"platform"`
+
+	// RenderedTemplates holds the output of every template registered via
+	// WithTemplate, keyed by name.
+	RenderedTemplates map[string]string `json:"templates,omitempty"`
+
+	// Modules lists the resolved dependency modules recorded by the Go
+	// toolchain, for supply-chain metadata such as CycloneDXJSON.
+	Modules []ModuleInfo `json:"modules,omitempty"`
+	// BuildSettings mirrors debug.BuildInfo.Settings (GOOS, GOARCH,
+	// -trimpath, CGO flags, ...).
+	BuildSettings map[string]string `json:"buildSettings,omitempty"`
+
+	branchStrategy      BranchStrategy
+	gitDescribeEnabled  bool
+	gitDescribeRepoPath string
+	gitCommitDistance   int
+	templates           map[string]string
+}
+
+// Option configures the Info returned by GetVersionInfo.
+type Option func(*Info)
+
+// WithASCIIName sets an ASCII art banner printed above the version details.
+func WithASCIIName(art string) Option {
+	return func(i *Info) {
+		i.ASCIIName = art
+	}
+}
+
+// WithAppDetails sets the application name, description and homepage URL.
+func WithAppDetails(name, description, url string) Option {
+	return func(i *Info) {
+		i.AppName = name
+		i.AppDescription = description
+		i.URL = url
+	}
+}
+
+// WithBuiltBy records who/what produced the binary, e.g. "goreleaser" or
+// "nixpkgs".
+func WithBuiltBy(builtBy string) Option {
+	return func(i *Info) {
+		i.BuiltBy = builtBy
+	}
+}
+
+// WithBranchStrategy derives GitVersion from Info.GitBranch using the
+// given BranchStrategy, once every other Option has been applied.
+func WithBranchStrategy(strategy BranchStrategy) Option {
+	return func(i *Info) {
+		i.branchStrategy = strategy
+	}
+}
+
+// WithGitFlowEnabled derives GitVersion from the current branch following
+// Git-Flow conventions.
+//
+// Deprecated: use WithBranchStrategy(GitFlowStrategy{}) instead.
+func WithGitFlowEnabled(enabled bool) Option {
+	return func(i *Info) {
+		if enabled {
+			i.branchStrategy = GitFlowStrategy{}
+		}
+	}
+}
+
+// WithTemplate registers a Go template under name, to be rendered against
+// the build's version context and exposed through RenderedTemplates,
+// String and JSONString. Typical usage embeds this module into build
+// tooling that assembles `-ldflags` strings, e.g.
+// `WithTemplate("ldflags", "-X pkg.Version={{.Git.Tag}}")`.
+func WithTemplate(name, tmpl string) Option {
+	return func(i *Info) {
+		if i.templates == nil {
+			i.templates = map[string]string{}
+		}
+		i.templates[name] = tmpl
+	}
+}
+
+// GetVersionInfo collects the build and VCS metadata embedded by the Go
+// toolchain via debug.ReadBuildInfo, applies the given options, and
+// resolves the final GitVersion according to the configured branch
+// strategy.
+func GetVersionInfo(opts ...Option) Info {
+	info := Info{
+		GitVersion: unknown,
+		GitCommit:  unknown,
+		GoVersion:  runtime.Version(),
+		Compiler:   runtime.Compiler,
+		Platform:   fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+	}
+
+	bi, _ := debug.ReadBuildInfo()
+	if bi != nil {
+		info.GitVersion = firstNonEmpty(getGitVersion(bi), info.GitVersion)
+		info.GitCommit = firstNonEmpty(getKey(bi, "vcs.revision"), info.GitCommit)
+		info.GitTreeState = getDirty(bi)
+		info.BuildDate = getBuildDate(bi)
+		info.GitBranch = getBranch(bi)
+		info.Modules = moduleInfos(bi)
+		info.BuildSettings = buildSettings(bi)
+	}
+
+	for _, opt := range opts {
+		opt(&info)
+	}
+
+	if info.gitDescribeEnabled {
+		repoPath := firstNonEmpty(info.gitDescribeRepoPath, ".")
+		describe, tag, distance := getGitDescribe(bi, repoPath)
+		if describe != "" {
+			info.GitVersion = describe
+		}
+		if tag != "" {
+			info.GitTag = tag
+		}
+		info.gitCommitDistance = distance
+	}
+
+	if info.branchStrategy != nil {
+		info.GitVersion = info.branchStrategy.Apply(&info)
+	}
+
+	if len(info.templates) > 0 {
+		info.RenderedTemplates = make(map[string]string, len(info.templates))
+		for name, tmpl := range info.templates {
+			rendered, err := info.Render(tmpl)
+			if err != nil {
+				rendered = fmt.Sprintf("error rendering template %q: %v", name, err)
+			}
+			info.RenderedTemplates[name] = rendered
+		}
+	}
+
+	return info
+}
+
+// String renders a human readable version banner.
+func (i Info) String() string {
+	var sb strings.Builder
+
+	if i.ASCIIName != "" {
+		sb.WriteString(i.ASCIIName)
+		sb.WriteString("\n")
+	}
+	if i.AppName != "" {
+		fmt.Fprintf(&sb, "%s - %s\n", i.AppName, i.AppDescription)
+	}
+	if i.URL != "" {
+		fmt.Fprintf(&sb, "%s\n", i.URL)
+	}
+
+	fmt.Fprintf(&sb, "GitVersion:   %s\n", i.GitVersion)
+	if i.GitTag != "" {
+		fmt.Fprintf(&sb, "GitTag:       %s\n", i.GitTag)
+	}
+	fmt.Fprintf(&sb, "GitCommit:    %s\n", i.GitCommit)
+	fmt.Fprintf(&sb, "GitTreeState: %s\n", i.GitTreeState)
+	fmt.Fprintf(&sb, "GitBranch:    %s\n", i.GitBranch)
+	fmt.Fprintf(&sb, "BuildDate:    %s\n", i.BuildDate)
+	fmt.Fprintf(&sb, "BuiltBy:      %s\n", i.BuiltBy)
+	fmt.Fprintf(&sb, "GoVersion:    %s\n", i.GoVersion)
+	fmt.Fprintf(&sb, "Compiler:     %s\n", i.Compiler)
+	fmt.Fprintf(&sb, "Platform:     %s\n", i.Platform)
+
+	if len(i.RenderedTemplates) > 0 {
+		sb.WriteString("Templates:\n")
+		for name, rendered := range i.RenderedTemplates {
+			fmt.Fprintf(&sb, "  %s: %s\n", name, rendered)
+		}
+	}
+
+	return sb.String()
+}
+
+// JSONString renders Info as indented JSON.
+func (i Info) JSONString() ([]byte, error) {
+	return json.MarshalIndent(i, "", "  ")
+}
+
+// getGitVersion extracts the module version embedded by the Go toolchain,
+// ignoring the synthetic "(devel)" placeholder used for unversioned builds.
+func getGitVersion(bi *debug.BuildInfo) string {
+	if bi == nil {
+		return ""
+	}
+	if bi.Main.Version == "" || bi.Main.Version == "(devel)" {
+		return ""
+	}
+	return bi.Main.Version
+}
+
+// getDirty reports the working tree state recorded by the Go toolchain.
+func getDirty(bi *debug.BuildInfo) string {
+	switch getKey(bi, "vcs.modified") {
+	case "true":
+		return "dirty"
+	case "false":
+		return "clean"
+	default:
+		return ""
+	}
+}
+
+// getBranch returns the current Git branch, when the build was produced
+// from a Git checkout.
+func getBranch(bi *debug.BuildInfo) string {
+	if getKey(bi, "vcs") != "git" {
+		return ""
+	}
+
+	out, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// getBuildDate parses the commit timestamp recorded by the Go toolchain
+// and formats it without its trailing "Z", matching the rest of Info's
+// fields.
+func getBuildDate(bi *debug.BuildInfo) string {
+	value := getKey(bi, "vcs.time")
+	if value == "" {
+		return ""
+	}
+
+	t, err := time.Parse("2006-01-02T15:04:05Z", value)
+	if err != nil {
+		return ""
+	}
+	return t.Format("2006-01-02T15:04:05")
+}
+
+// getKey looks up a build setting by key, returning "" when absent.
+func getKey(bi *debug.BuildInfo, key string) string {
+	if bi == nil {
+		return ""
+	}
+	for _, s := range bi.Settings {
+		if s.Key == key {
+			return s.Value
+		}
+	}
+	return ""
+}
+
+// firstNonEmpty returns the first non-empty value, or "" if all are empty.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}